@@ -0,0 +1,107 @@
+package zek
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// NamespaceMode controls how StructWriter handles XML namespaces when two
+// elements or attributes share a local name but live in different
+// namespaces.
+type NamespaceMode int
+
+const (
+	// NamespaceIgnore drops namespace information entirely (the historical
+	// behavior): only the local name is used for Go names and struct tags.
+	NamespaceIgnore NamespaceMode = iota
+	// NamespaceQualifiedTags keeps a single Go name per local name, but
+	// qualifies struct tags with the namespace URI, as encoding/xml
+	// expects: `xml:"http://example.org/ns foo"`.
+	NamespaceQualifiedTags
+	// NamespacePrefixedNames derives a short prefix per namespace URI and
+	// prepends it to the generated Go type/field name, so elements or
+	// attributes that collide on local name but differ in namespace no
+	// longer clash.
+	NamespacePrefixedNames
+)
+
+// goName returns the Go type or field name for name, applying a namespace
+// prefix when NamespaceMode is NamespacePrefixedNames.
+func (sw *StructWriter) goName(name xml.Name) string {
+	base := sw.NameFunc(name.Local)
+	if sw.NamespaceMode != NamespacePrefixedNames || name.Space == "" {
+		return base
+	}
+	return sw.NameFunc(sw.namespacePrefix(name.Space)) + base
+}
+
+// xmlTagName returns the identifier used inside an xml struct tag for name,
+// qualifying it with the namespace URI when NamespaceMode is
+// NamespaceQualifiedTags, as encoding/xml expects.
+func (sw *StructWriter) xmlTagName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	if sw.NamespaceMode == NamespaceQualifiedTags {
+		sw.namespacePrefix(name.Space) // Register, even though the tag itself uses the raw URI.
+		return name.Space + " " + name.Local
+	}
+	return name.Local
+}
+
+// namespacePrefix returns the short prefix for a namespace URI, consulting
+// NamespacePrefixes first and otherwise deriving one from the URI's last
+// non-empty path segment. Every namespace looked up this way is recorded
+// for RegisterNamespaces.
+func (sw *StructWriter) namespacePrefix(space string) string {
+	prefix, ok := sw.NamespacePrefixes[space]
+	if !ok {
+		if sw.namespacePrefixCache == nil {
+			sw.namespacePrefixCache = make(map[string]string)
+		}
+		if cached, ok := sw.namespacePrefixCache[space]; ok {
+			prefix = cached
+		} else {
+			prefix = sw.uniquePrefix(derivePrefix(space), space)
+			sw.namespacePrefixCache[space] = prefix
+		}
+	} else {
+		prefix = sw.uniquePrefix(prefix, space)
+	}
+	if sw.seenNamespaces == nil {
+		sw.seenNamespaces = make(map[string]string)
+	}
+	sw.seenNamespaces[prefix] = space
+	return prefix
+}
+
+// uniquePrefix returns base, or base suffixed with 2, 3, ... if base (or a
+// prior numbered variant) was already handed out to a namespace URI other
+// than space. This applies to both auto-derived prefixes - where two URIs
+// whose last path segment matches (e.g. "http://a.org/soap" and
+// "http://b.org/soap") would otherwise derive the same prefix - and
+// explicit NamespacePrefixes entries, which could otherwise steal a prefix
+// already auto-derived for an unrelated namespace. Either way, the
+// colliding prefix would silently overwrite the first URI's entry in
+// RegisterNamespaces and merge their fields under one Go name.
+func (sw *StructWriter) uniquePrefix(base, space string) string {
+	prefix := base
+	for i := 2; sw.seenNamespaces[prefix] != "" && sw.seenNamespaces[prefix] != space; i++ {
+		prefix = fmt.Sprintf("%s%d", base, i)
+	}
+	return prefix
+}
+
+// derivePrefix turns a namespace URI into a short, lower-case prefix from
+// its last non-empty path segment, e.g. "http://example.org/ns/book" ->
+// "book", or "urn:soap:envelope" -> "envelope".
+func derivePrefix(space string) string {
+	trimmed := strings.TrimRight(space, "/")
+	for _, sep := range []string{"/", ":"} {
+		if i := strings.LastIndex(trimmed, sep); i >= 0 && i+1 < len(trimmed) {
+			trimmed = trimmed[i+1:]
+		}
+	}
+	return strings.ToLower(trimmed)
+}
@@ -0,0 +1,162 @@
+package zek
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+// soapNode builds a tiny SOAP-style tree: an Envelope in one namespace
+// wrapping a Header and a Body, each in their own namespace, with a child
+// named "Id" in both the header and the body namespace to exercise the
+// name-clash case NamespacePrefixedNames exists to solve.
+func soapNode() *Node {
+	envelopeNS := "http://schemas.xmlsoap.org/soap/envelope/"
+	headerNS := "http://example.org/header"
+	bodyNS := "http://example.org/body"
+	return &Node{
+		Name: xml.Name{Space: envelopeNS, Local: "Envelope"},
+		Children: []*Node{
+			{
+				Name: xml.Name{Space: headerNS, Local: "Header"},
+				Children: []*Node{
+					{Name: xml.Name{Space: headerNS, Local: "Id"}, Examples: []string{"h1"}},
+				},
+			},
+			{
+				Name: xml.Name{Space: bodyNS, Local: "Body"},
+				Children: []*Node{
+					{Name: xml.Name{Space: bodyNS, Local: "Id"}, Examples: []string{"b1"}},
+				},
+			},
+		},
+	}
+}
+
+func TestStructWriterNamespaceIgnore(t *testing.T) {
+	var buf bytes.Buffer
+	sw := NewStructWriter(&buf)
+	sw.Banner = ""
+	if err := sw.WriteNode(soapNode()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "RegisterNamespaces") {
+		t.Fatalf("NamespaceIgnore should not emit RegisterNamespaces, got:\n%s", out)
+	}
+}
+
+func TestStructWriterNamespaceQualifiedTags(t *testing.T) {
+	var buf bytes.Buffer
+	sw := NewStructWriter(&buf)
+	sw.Banner = ""
+	sw.NamespaceMode = NamespaceQualifiedTags
+	if err := sw.WriteNode(soapNode()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `xml:"http://example.org/header Header"`) {
+		t.Fatalf("expected qualified tag for Header, got:\n%s", out)
+	}
+	if !strings.Contains(out, "func RegisterNamespaces() map[string]string {") {
+		t.Fatalf("expected RegisterNamespaces helper, got:\n%s", out)
+	}
+}
+
+func TestStructWriterNamespacePrefixedNames(t *testing.T) {
+	var buf bytes.Buffer
+	sw := NewStructWriter(&buf)
+	sw.Banner = ""
+	sw.NamespaceMode = NamespacePrefixedNames
+	if err := sw.WriteNode(soapNode()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "HeaderID") {
+		t.Fatalf("expected prefixed name HeaderID, got:\n%s", out)
+	}
+	if !strings.Contains(out, "BodyID") {
+		t.Fatalf("expected prefixed name BodyID, got:\n%s", out)
+	}
+}
+
+func TestStructWriterNamespacePrefixedNamesDedupesCollidingPrefixes(t *testing.T) {
+	aNS := "http://a.org/soap"
+	bNS := "http://b.org/soap"
+	node := &Node{
+		Name: xml.Name{Space: aNS, Local: "Envelope"},
+		Children: []*Node{
+			{Name: xml.Name{Space: aNS, Local: "Id"}, Examples: []string{"a1"}},
+			{Name: xml.Name{Space: bNS, Local: "Id"}, Examples: []string{"b1"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	sw := NewStructWriter(&buf)
+	sw.Banner = ""
+	sw.NamespaceMode = NamespacePrefixedNames
+	if err := sw.WriteNode(node); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "SoapID") {
+		t.Fatalf("expected prefixed name SoapID for the first namespace, got:\n%s", out)
+	}
+	if !strings.Contains(out, "Soap2ID") {
+		t.Fatalf("expected de-duped prefixed name Soap2ID for the colliding namespace, got:\n%s", out)
+	}
+
+	namespaces := sw.seenNamespaces
+	if namespaces["soap"] != aNS || namespaces["soap2"] != bNS {
+		t.Fatalf("expected soap/soap2 to map to distinct URIs, got: %+v", namespaces)
+	}
+}
+
+func TestStructWriterNamespacePrefixedNamesDedupesExplicitPrefixAgainstDerived(t *testing.T) {
+	aNS := "http://a.org/foo"
+	bNS := "http://b.org/bar"
+	node := &Node{
+		Name: xml.Name{Space: aNS, Local: "Envelope"},
+		Children: []*Node{
+			{Name: xml.Name{Space: aNS, Local: "Id"}, Examples: []string{"a1"}},
+			{Name: xml.Name{Space: bNS, Local: "Id"}, Examples: []string{"b1"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	sw := NewStructWriter(&buf)
+	sw.Banner = ""
+	sw.NamespaceMode = NamespacePrefixedNames
+	// aNS auto-derives to "foo"; explicitly pointing bNS at the same prefix
+	// must not let it steal "foo" out from under aNS.
+	sw.NamespacePrefixes = map[string]string{bNS: "foo"}
+	if err := sw.WriteNode(node); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "FooID") {
+		t.Fatalf("expected prefixed name FooID for aNS, got:\n%s", out)
+	}
+	if !strings.Contains(out, "Foo2ID") {
+		t.Fatalf("expected de-duped prefixed name Foo2ID for the explicitly-colliding namespace, got:\n%s", out)
+	}
+
+	namespaces := sw.seenNamespaces
+	if namespaces["foo"] != aNS || namespaces["foo2"] != bNS {
+		t.Fatalf("expected foo/foo2 to map to distinct URIs, got: %+v", namespaces)
+	}
+}
+
+func TestDerivePrefix(t *testing.T) {
+	cases := map[string]string{
+		"http://example.org/ns/book":                "book",
+		"http://schemas.xmlsoap.org/soap/envelope/": "envelope",
+		"urn:soap:header":                           "header",
+	}
+	for in, want := range cases {
+		if got := derivePrefix(in); got != want {
+			t.Errorf("derivePrefix(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package zek
+
+import "encoding/xml"
+
+// Attr represents an observed XML attribute together with every value seen
+// for it across the sampled document(s). StructWriter uses Examples to
+// infer the narrowest Go type that still fits all observations, and
+// SchemaWriter uses Count, against the owning Node's Count, to tell a
+// required attribute from an optional one.
+type Attr struct {
+	Name     xml.Name
+	Examples []string
+	Count    int // Number of times this attribute was observed across all of Node's occurrences.
+}
+
+// Node represents a single kind of XML element, as observed across one or
+// more sample documents. Sibling elements with the same tag name collapse
+// into a single Node, accumulating Examples and Attr along the way.
+type Node struct {
+	Name     xml.Name
+	Attr     []Attr
+	Children []*Node
+	Examples []string
+	Count    int // Number of times this element was observed under its parent.
+
+	multivalued bool
+}
+
+// IsMultivalued returns true, if this node has been observed more than once
+// under its parent element.
+func (n *Node) IsMultivalued() bool {
+	return n.multivalued
+}
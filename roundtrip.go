@@ -0,0 +1,251 @@
+package zek
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LossyField is a field whose value changed, or disappeared, across a
+// round trip through a generated struct. Node points back into the tree
+// StructWriter consumed to generate the field, so a following call to
+// WriteNode can annotate the offending field via StructWriter.LossyFields.
+type LossyField struct {
+	Path   string // Dotted path to the field, e.g. "Book.Author".
+	Node   *Node  // The node the lossy field was generated from, or nil if root was not given to RoundTrip or no node in it matches Path.
+	Reason string // e.g. "attribute isbn", "attribute count differs", "chardata", "mixed content", "child count differs"
+}
+
+// RoundTripReport is the result of RoundTrip: whether the generated struct
+// captured sample losslessly, and if not, which fields were lossy.
+type RoundTripReport struct {
+	OK    bool
+	Lossy []LossyField
+}
+
+// ExitCode returns 0 if the round trip was lossless, 1 otherwise, matching
+// what a `zek -verify sample.xml generated.go` CLI invocation would want to
+// return to its shell.
+func (r *RoundTripReport) ExitCode() int {
+	if r == nil || r.OK {
+		return 0
+	}
+	return 1
+}
+
+// RoundTrip writes source (as produced by StructWriter, a "type TypeName
+// struct {...}" declaration and any helper types) to a temporary package,
+// compiles it, unmarshals sample into an instance of typeName, re-marshals
+// it, and diffs the canonicalized forms of the two documents (sorted
+// attributes, normalized whitespace). root, if not nil, is the *Node tree
+// source was generated from, used to attach the matching Node to each
+// LossyField.
+func RoundTrip(sample []byte, source, typeName string, root *Node) (*RoundTripReport, error) {
+	dir, err := ioutil.TempDir("", "zek-roundtrip-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := writeRoundTripPackage(dir, source, typeName); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "sample.xml"), sample, 0644); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("zek: round-trip build/run failed: %v: %s", err, stderr.String())
+	}
+
+	report, err := diffCanonical(sample, stdout.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if root != nil {
+		for i := range report.Lossy {
+			report.Lossy[i].Node = findNode(root, strings.Split(report.Lossy[i].Path, "."))
+		}
+	}
+	return report, nil
+}
+
+// roundTripMainTmpl is filled in with the generated source (%[1]s) and the
+// top-level type name (%[2]s) to produce a standalone program that
+// unmarshals sample.xml and writes the re-marshaled document to stdout.
+const roundTripMainTmpl = `package main
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+)
+
+%[1]s
+
+func main() {
+	data, err := ioutil.ReadFile("sample.xml")
+	if err != nil {
+		panic(err)
+	}
+	var v %[2]s
+	if err := xml.Unmarshal(data, &v); err != nil {
+		panic(err)
+	}
+	out, err := xml.Marshal(&v)
+	if err != nil {
+		panic(err)
+	}
+	os.Stdout.Write(out)
+}
+`
+
+// writeRoundTripPackage writes a throwaway module into dir, embedding
+// source into a runnable main package.
+func writeRoundTripPackage(dir, source, typeName string) error {
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module zek-roundtrip\n\ngo 1.18\n"), 0644); err != nil {
+		return err
+	}
+	body := fmt.Sprintf(roundTripMainTmpl, source, typeName)
+	if strings.Contains(source, "time.") {
+		body = strings.Replace(body, "\"encoding/xml\"\n\t\"io/ioutil\"", "\"encoding/xml\"\n\t\"io/ioutil\"\n\t\"time\"", 1)
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(body), 0644)
+}
+
+// cNode is a canonicalized XML element: attributes sorted by name, text
+// trimmed of leading/trailing whitespace, ready for a structural diff that
+// ignores attribute order and incidental whitespace.
+type cNode struct {
+	Name     string
+	Attrs    []xml.Attr
+	Text     string
+	Children []*cNode
+}
+
+// canonicalize parses data into a cNode tree.
+func canonicalize(data []byte) (*cNode, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*cNode
+	var root *cNode
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &cNode{Name: t.Name.Local, Attrs: append([]xml.Attr{}, t.Attr...)}
+			sort.Slice(n.Attrs, func(i, j int) bool { return n.Attrs[i].Name.Local < n.Attrs[j].Name.Local })
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, n)
+			} else {
+				root = n
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].Text += string(t)
+			}
+		}
+	}
+	if root != nil {
+		trimText(root)
+	}
+	return root, nil
+}
+
+// trimText normalizes whitespace-only or surrounding whitespace in text
+// content throughout the tree, so indentation differences between the
+// sample and the re-marshaled document are not reported as lossy.
+func trimText(n *cNode) {
+	n.Text = strings.TrimSpace(n.Text)
+	for _, c := range n.Children {
+		trimText(c)
+	}
+}
+
+// diffCanonical canonicalizes both documents and reports every field where
+// they disagree.
+func diffCanonical(sample, remarshaled []byte) (*RoundTripReport, error) {
+	a, err := canonicalize(sample)
+	if err != nil {
+		return nil, fmt.Errorf("zek: canonicalizing sample: %w", err)
+	}
+	b, err := canonicalize(remarshaled)
+	if err != nil {
+		return nil, fmt.Errorf("zek: canonicalizing round-tripped document: %w", err)
+	}
+	var lossy []LossyField
+	compareCanonical(a, b, a.Name, &lossy)
+	return &RoundTripReport{OK: len(lossy) == 0, Lossy: lossy}, nil
+}
+
+// compareCanonical walks a and b together, appending a LossyField for
+// every attribute, text or child mismatch found under path.
+func compareCanonical(a, b *cNode, path string, lossy *[]LossyField) {
+	if a == nil || b == nil || a.Name != b.Name {
+		*lossy = append(*lossy, LossyField{Path: path, Reason: "element missing or renamed"})
+		return
+	}
+	if len(a.Attrs) != len(b.Attrs) {
+		*lossy = append(*lossy, LossyField{Path: path, Reason: "attribute count differs"})
+	} else {
+		for i := range a.Attrs {
+			if a.Attrs[i].Name.Local != b.Attrs[i].Name.Local || a.Attrs[i].Value != b.Attrs[i].Value {
+				*lossy = append(*lossy, LossyField{Path: path, Reason: fmt.Sprintf("attribute %s", a.Attrs[i].Name.Local)})
+			}
+		}
+	}
+	if a.Text != b.Text {
+		reason := "mixed content"
+		if len(a.Children) == 0 {
+			reason = "chardata"
+		}
+		*lossy = append(*lossy, LossyField{Path: path, Reason: reason})
+	}
+	if len(a.Children) != len(b.Children) {
+		*lossy = append(*lossy, LossyField{Path: path, Reason: "child count differs"})
+		return
+	}
+	for i := range a.Children {
+		childPath := path + "." + a.Children[i].Name
+		compareCanonical(a.Children[i], b.Children[i], childPath, lossy)
+	}
+}
+
+// findNode walks root looking for the *Node matching path, a dotted chain
+// of element local names as produced by compareCanonical (root.local,
+// root.local.child, ...). It returns nil if no matching node is found.
+func findNode(root *Node, path []string) *Node {
+	if root == nil || len(path) == 0 || root.Name.Local != path[0] {
+		return nil
+	}
+	if len(path) == 1 {
+		return root
+	}
+	for _, child := range root.Children {
+		if n := findNode(child, path[1:]); n != nil {
+			return n
+		}
+	}
+	return nil
+}
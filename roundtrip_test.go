@@ -0,0 +1,76 @@
+package zek
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestDiffCanonicalIgnoresAttributeOrderAndWhitespace(t *testing.T) {
+	a := []byte(`<book isbn="1" edition="2">
+		<title>Go</title>
+	</book>`)
+	b := []byte(`<book edition="2" isbn="1"><title>Go</title></book>`)
+	report, err := diffCanonical(a, b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !report.OK {
+		t.Fatalf("expected lossless round trip, got lossy fields: %+v", report.Lossy)
+	}
+}
+
+func TestDiffCanonicalReportsLossyAttribute(t *testing.T) {
+	a := []byte(`<book isbn="123"></book>`)
+	b := []byte(`<book isbn="456"></book>`)
+	report, err := diffCanonical(a, b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.OK {
+		t.Fatalf("expected lossy round trip")
+	}
+	if len(report.Lossy) != 1 || report.Lossy[0].Reason != "attribute isbn" {
+		t.Fatalf("unexpected lossy fields: %+v", report.Lossy)
+	}
+}
+
+func TestDiffCanonicalReportsMixedContentLoss(t *testing.T) {
+	a := []byte(`<book>some text<author>A</author></book>`)
+	b := []byte(`<book><author>A</author></book>`)
+	report, err := diffCanonical(a, b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.OK {
+		t.Fatalf("expected lossy round trip for dropped mixed content")
+	}
+	found := false
+	for _, f := range report.Lossy {
+		if f.Reason == "mixed content" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected a mixed content finding, got: %+v", report.Lossy)
+	}
+}
+
+func TestFindNode(t *testing.T) {
+	child := &Node{Name: xml.Name{Local: "author"}}
+	root := &Node{Name: xml.Name{Local: "book"}, Children: []*Node{child}}
+	if got := findNode(root, []string{"book", "author"}); got != child {
+		t.Fatalf("expected to find author node, got %v", got)
+	}
+	if got := findNode(root, []string{"book"}); got != root {
+		t.Fatalf("expected to find root node")
+	}
+}
+
+func TestRoundTripReportExitCode(t *testing.T) {
+	if (&RoundTripReport{OK: true}).ExitCode() != 0 {
+		t.Fatalf("expected exit code 0 for lossless report")
+	}
+	if (&RoundTripReport{OK: false}).ExitCode() != 1 {
+		t.Fatalf("expected exit code 1 for lossy report")
+	}
+}
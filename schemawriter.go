@@ -0,0 +1,123 @@
+package zek
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SchemaWriter walks the same *Node tree StructWriter consumes and emits an
+// XML Schema (XSD) document describing it, so a corpus sampled with zek
+// can also be handed to consumers in other languages as a validatable
+// contract.
+type SchemaWriter struct {
+	w io.Writer
+
+	NameFunc        func(string) string // Turns tag names into xs:element names. Defaults to the identity function.
+	TargetNamespace string              // xs:schema targetNamespace, omitted if empty.
+	TypeInference   bool                // Infer xs:boolean, xs:int, xs:decimal and xs:dateTime from examples, instead of always xs:string.
+	TimeLayouts     []string            // Layouts tried when TypeInference looks for xs:dateTime. Defaults to DefaultTimeLayouts.
+}
+
+// NewSchemaWriter returns a SchemaWriter writing to w.
+func NewSchemaWriter(w io.Writer) *SchemaWriter {
+	return &SchemaWriter{
+		w:           w,
+		NameFunc:    func(s string) string { return s },
+		TimeLayouts: DefaultTimeLayouts,
+	}
+}
+
+// WriteNode writes node as the single top-level element of an XSD document.
+func (sw *SchemaWriter) WriteNode(node *Node) (err error) {
+	if sw.w == nil || node == nil {
+		return nil
+	}
+	sew := stickyErrWriter{w: sw.w, err: &err}
+	io.WriteString(sew, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	if sw.TargetNamespace != "" {
+		fmt.Fprintf(sew, "<xs:schema xmlns:xs=\"http://www.w3.org/2001/XMLSchema\" targetNamespace=\"%s\">\n", sw.TargetNamespace)
+	} else {
+		io.WriteString(sew, "<xs:schema xmlns:xs=\"http://www.w3.org/2001/XMLSchema\">\n")
+	}
+	sw.writeElement(sew, node, 1, false)
+	io.WriteString(sew, "</xs:schema>\n")
+	return err
+}
+
+// writeElement writes node as an xs:element with an inline xs:complexType,
+// indented by depth tabs. unbounded marks an element that may repeat under
+// its parent and therefore needs maxOccurs="unbounded" on the reference.
+func (sw *SchemaWriter) writeElement(w io.Writer, node *Node, depth int, unbounded bool) {
+	indent := strings.Repeat("\t", depth)
+	name := node.Name.Local
+	if sw.NameFunc != nil {
+		name = sw.NameFunc(name)
+	}
+	if len(node.Children) == 0 && len(node.Attr) == 0 {
+		t := sw.xsdType(node.Examples)
+		if unbounded {
+			fmt.Fprintf(w, "%s<xs:element name=\"%s\" type=\"%s\" maxOccurs=\"unbounded\"/>\n", indent, name, t)
+		} else {
+			fmt.Fprintf(w, "%s<xs:element name=\"%s\" type=\"%s\"/>\n", indent, name, t)
+		}
+		return
+	}
+
+	mixed := len(node.Examples) > 0 && len(node.Children) > 0
+
+	if unbounded {
+		fmt.Fprintf(w, "%s<xs:element name=\"%s\" maxOccurs=\"unbounded\">\n", indent, name)
+	} else {
+		fmt.Fprintf(w, "%s<xs:element name=\"%s\">\n", indent, name)
+	}
+	if mixed {
+		fmt.Fprintf(w, "%s\t<xs:complexType mixed=\"true\">\n", indent)
+	} else {
+		fmt.Fprintf(w, "%s\t<xs:complexType>\n", indent)
+	}
+
+	if len(node.Children) > 0 {
+		fmt.Fprintf(w, "%s\t\t<xs:sequence>\n", indent)
+		for _, child := range node.Children {
+			sw.writeElement(w, child, depth+3, child.IsMultivalued())
+		}
+		fmt.Fprintf(w, "%s\t\t</xs:sequence>\n", indent)
+	}
+	for _, attr := range node.Attr {
+		use := "optional"
+		if node.Count > 0 && attr.Count >= node.Count {
+			use = "required"
+		}
+		t := sw.xsdType(attr.Examples)
+		fmt.Fprintf(w, "%s\t\t<xs:attribute name=\"%s\" type=\"%s\" use=\"%s\"/>\n", indent, attr.Name.Local, t, use)
+	}
+
+	fmt.Fprintf(w, "%s\t</xs:complexType>\n", indent)
+	fmt.Fprintf(w, "%s</xs:element>\n", indent)
+}
+
+// xsdType picks an XSD built-in type for a field from its observed
+// examples, reusing StructWriter's type inference. With TypeInference
+// disabled, every field is xs:string.
+func (sw *SchemaWriter) xsdType(examples []string) string {
+	if !sw.TypeInference {
+		return "xs:string"
+	}
+	layouts := sw.TimeLayouts
+	if len(layouts) == 0 {
+		layouts = DefaultTimeLayouts
+	}
+	switch inferType(examples, layouts).name {
+	case "bool":
+		return "xs:boolean"
+	case "int64":
+		return "xs:int"
+	case "float64":
+		return "xs:decimal"
+	case "time.Time":
+		return "xs:dateTime"
+	default:
+		return "xs:string"
+	}
+}
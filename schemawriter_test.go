@@ -0,0 +1,60 @@
+package zek
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestSchemaWriterAttributeUse(t *testing.T) {
+	node := &Node{
+		Name:  xml.Name{Local: "book"},
+		Count: 2,
+		Attr: []Attr{
+			{Name: xml.Name{Local: "isbn"}, Examples: []string{"123", "456"}, Count: 2},
+			{Name: xml.Name{Local: "edition"}, Examples: []string{"1"}, Count: 1},
+		},
+	}
+	var buf bytes.Buffer
+	if err := NewSchemaWriter(&buf).WriteNode(node); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `<xs:attribute name="isbn" type="xs:string" use="required"/>`) {
+		t.Fatalf("expected required isbn attribute, got:\n%s", out)
+	}
+	if !strings.Contains(out, `<xs:attribute name="edition" type="xs:string" use="optional"/>`) {
+		t.Fatalf("expected optional edition attribute, got:\n%s", out)
+	}
+}
+
+func TestSchemaWriterMixedContentAndMultivalued(t *testing.T) {
+	child := &Node{Name: xml.Name{Local: "author"}, Examples: []string{"A", "B"}}
+	child.multivalued = true
+	node := &Node{
+		Name:     xml.Name{Local: "book"},
+		Examples: []string{"some text"},
+		Children: []*Node{child},
+	}
+	var buf bytes.Buffer
+	if err := NewSchemaWriter(&buf).WriteNode(node); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `mixed="true"`) {
+		t.Fatalf("expected mixed content, got:\n%s", out)
+	}
+	if !strings.Contains(out, `maxOccurs="unbounded"`) {
+		t.Fatalf("expected maxOccurs=unbounded for multivalued child, got:\n%s", out)
+	}
+}
+
+func TestSchemaWriterTypeInference(t *testing.T) {
+	node := &Node{Name: xml.Name{Local: "count"}, Examples: []string{"1", "2"}}
+	sw := NewSchemaWriter(&bytes.Buffer{})
+	sw.TypeInference = true
+	if got := sw.xsdType(node.Examples); got != "xs:int" {
+		t.Fatalf("got %s, want xs:int", got)
+	}
+}
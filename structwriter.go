@@ -1,13 +1,13 @@
 package zek
 
 import (
-	"encoding/xml"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"os/user"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 )
@@ -88,9 +88,21 @@ type StructWriter struct {
 	Banner            string              // Autogenerated note.
 	ExampleMaxChars   int                 // Max length of example comment.
 	Strict            bool                // Whether to ignore implementation holes.
-	WithJSONTags      bool                // Include JSON struct tags.
+	WithJSONTags      bool                // Include JSON struct tags. Deprecated: append JSONTagEmitter{} to TagEmitters instead.
+	TagEmitters       []TagEmitter        // Additional struct tags to emit per field, e.g. yaml, toml, bson, db.
 	Compact           bool                // Emit more compact struct.
 	UniqueExamples    bool                // Filter out duplicated examples
+	TypeInference     bool                // Infer bool, int64, float64 and time.Time fields from examples, instead of always using string.
+	TimeLayouts       []string            // Layouts tried, in order, when TypeInference finds a time.Time field. Defaults to DefaultTimeLayouts.
+	NamespaceMode     NamespaceMode       // How to handle elements/attributes that differ only by namespace. Defaults to NamespaceIgnore.
+	NamespacePrefixes map[string]string   // Namespace URI to short prefix, for NamespacePrefixedNames. Auto-derived if a namespace is missing here.
+	LossyFields       map[*Node]string    // Node to RoundTrip failure reason; annotates the field generated from it with "// zek: lossy (reason)".
+
+	timeHelpers          []timeHelper      // Named helper types to emit after the struct, one per inferred time.Time field.
+	timeHelperNames      map[string]bool   // Helper type names already handed out, to avoid clashes.
+	typeNames            map[string]bool   // goName of every node in the tree being written, so registerTimeHelper can avoid colliding with a struct/type name.
+	namespacePrefixCache map[string]string // Auto-derived prefixes, keyed by namespace URI.
+	seenNamespaces       map[string]string // Every namespace prefix handed out, keyed by prefix, for RegisterNamespaces.
 }
 
 // NewStructWriter can write a node to a given writer. Default list of
@@ -116,6 +128,7 @@ func NewStructWriter(w io.Writer) *StructWriter {
 		AttributePrefixes: DefaultAttributePrefixes,
 		Banner:            banner,
 		ExampleMaxChars:   25,
+		TimeLayouts:       DefaultTimeLayouts,
 	}
 }
 
@@ -127,16 +140,206 @@ func (sw *StructWriter) WriteNode(node *Node) (err error) {
 	if node == nil || reflect.DeepEqual(node, new(Node)) {
 		return nil
 	}
-	return sw.writeNode(node, true)
+	sw.collectTypeNames(node)
+	if err := sw.writeNode(node, true); err != nil {
+		return err
+	}
+	if err := sw.writeTimeHelpers(sw.w); err != nil {
+		return err
+	}
+	return sw.writeNamespaceHelper(sw.w)
+}
+
+// collectTypeNames records the goName of node and every descendant into
+// sw.typeNames, so registerTimeHelper can mint a helper name that clashes
+// with none of them, even one nested deep in the tree.
+func (sw *StructWriter) collectTypeNames(node *Node) {
+	if sw.typeNames == nil {
+		sw.typeNames = make(map[string]bool)
+	}
+	sw.typeNames[sw.goName(node.Name)] = true
+	for _, child := range node.Children {
+		sw.collectTypeNames(child)
+	}
+}
+
+// writeNamespaceHelper emits a RegisterNamespaces function listing every
+// namespace URI and prefix used while generating the struct, ordered by
+// prefix, so downstream marshaling can be configured to match.
+func (sw *StructWriter) writeNamespaceHelper(w io.Writer) error {
+	if len(sw.seenNamespaces) == 0 {
+		return nil
+	}
+	prefixes := make([]string, 0, len(sw.seenNamespaces))
+	for p := range sw.seenNamespaces {
+		prefixes = append(prefixes, p)
+	}
+	sort.Strings(prefixes)
+
+	var b strings.Builder
+	b.WriteString("\n// RegisterNamespaces returns the namespace URI for every prefix used\n")
+	b.WriteString("// while generating this struct, so callers can configure an\n")
+	b.WriteString("// xml.Encoder/Decoder that round-trips the same prefixes.\n")
+	b.WriteString("func RegisterNamespaces() map[string]string {\n\treturn map[string]string{\n")
+	for _, p := range prefixes {
+		fmt.Fprintf(&b, "\t\t%q: %q,\n", p, sw.seenNamespaces[p])
+	}
+	b.WriteString("\t}\n}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// typeForExamples returns the Go type StructWriter should use for a field,
+// given the values observed for it. With TypeInference disabled, this is
+// always string, matching historical behavior. baseName seeds the name of
+// any generated time.Time helper type, see registerTimeHelper.
+func (sw *StructWriter) typeForExamples(baseName string, examples []string) goType {
+	if !sw.TypeInference {
+		return goType{name: "string"}
+	}
+	layouts := sw.TimeLayouts
+	if len(layouts) == 0 {
+		layouts = DefaultTimeLayouts
+	}
+	t := inferType(examples, layouts)
+	if t.name == "time.Time" {
+		t.name = sw.registerTimeHelper(baseName, t.layout)
+	}
+	return t
+}
+
+// timeHelper describes a named type StructWriter emits after the main
+// struct, so a time.Time field can be parsed with a layout encoding/xml
+// does not understand natively.
+type timeHelper struct {
+	name   string
+	layout string
+}
+
+// registerTimeHelper returns the name of a package-level helper type that
+// parses a time.Time field using layout, minting FooTime, FooTime2, ... as
+// needed so the name never clashes with another field's helper elsewhere
+// in the same document, nor with any generated struct/type name.
+func (sw *StructWriter) registerTimeHelper(baseName, layout string) string {
+	if sw.timeHelperNames == nil {
+		sw.timeHelperNames = make(map[string]bool)
+	}
+	name := baseName + "Time"
+	for i := 2; sw.timeHelperNames[name] || sw.typeNames[name]; i++ {
+		name = fmt.Sprintf("%sTime%d", baseName, i)
+	}
+	sw.timeHelperNames[name] = true
+	sw.timeHelpers = append(sw.timeHelpers, timeHelper{name: name, layout: layout})
+	return name
+}
+
+// timeHelperTmpl is filled in with a helper type's name (%[1]s) and time
+// layout (%[2]q) to produce a type that round-trips through both element
+// chardata and attribute values.
+const timeHelperTmpl = `
+// %[1]s wraps time.Time to parse and emit the %[2]q layout, since
+// encoding/xml only understands RFC3339 natively.
+type %[1]s struct {
+	time.Time
+}
+
+// UnmarshalXML parses chardata using the %[2]q layout.
+func (t *%[1]s) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var s string
+	if err := d.DecodeElement(&s, &start); err != nil {
+		return err
+	}
+	if s == "" {
+		return nil
+	}
+	parsed, err := time.Parse(%[2]q, s)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+// MarshalXML emits the time using the %[2]q layout.
+func (t %[1]s) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(t.Time.Format(%[2]q), start)
+}
+
+// UnmarshalXMLAttr parses an attribute value using the %[2]q layout.
+func (t *%[1]s) UnmarshalXMLAttr(attr xml.Attr) error {
+	if attr.Value == "" {
+		return nil
+	}
+	parsed, err := time.Parse(%[2]q, attr.Value)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+// MarshalXMLAttr emits the time as an attribute using the %[2]q layout.
+func (t %[1]s) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: name, Value: t.Time.Format(%[2]q)}, nil
+}
+
+// UnmarshalText parses a ",chardata" field value using the %[2]q layout.
+func (t *%[1]s) UnmarshalText(b []byte) error {
+	return t.UnmarshalXMLAttr(xml.Attr{Value: string(b)})
+}
+
+// MarshalText emits a ",chardata" field value using the %[2]q layout.
+func (t %[1]s) MarshalText() ([]byte, error) {
+	return []byte(t.Time.Format(%[2]q)), nil
+}
+`
+
+// writeTimeHelpers emits, for every time.Time field inferred while writing
+// node, a named type with UnmarshalXML/UnmarshalXMLAttr and MarshalXML/
+// MarshalXMLAttr methods for its layout.
+func (sw *StructWriter) writeTimeHelpers(w io.Writer) error {
+	for _, h := range sw.timeHelpers {
+		if _, err := fmt.Fprintf(w, timeHelperTmpl, h.name, h.layout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tagEmitters returns the TagEmitters to apply, folding in a JSONTagEmitter
+// for backward compatibility if WithJSONTags is set and TagEmitters does
+// not already contain one.
+func (sw *StructWriter) tagEmitters() []TagEmitter {
+	if !sw.WithJSONTags {
+		return sw.TagEmitters
+	}
+	for _, te := range sw.TagEmitters {
+		if te.Key() == "json" {
+			return sw.TagEmitters
+		}
+	}
+	return append(append([]TagEmitter{}, sw.TagEmitters...), JSONTagEmitter{})
+}
+
+// structTag assembles a full struct tag string, combining the given raw xml
+// tag content with every configured TagEmitter's contribution for this
+// field.
+func (sw *StructWriter) structTag(xmlTag string, kind FieldKind, localName, goName string, multivalued, optional bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "`xml:\"%s\"", xmlTag)
+	for _, te := range sw.tagEmitters() {
+		if v := te.Tag(kind, localName, goName, multivalued, optional); v != "" {
+			fmt.Fprintf(&b, " %s:\"%s\"", te.Key(), v)
+		}
+	}
+	b.WriteString("`")
+	return b.String()
 }
 
 // writeField writes a field with a simple xml struct tag to writer.
 func (sw *StructWriter) writeNameField(w io.Writer, node *Node) (int, error) {
-	if sw.WithJSONTags {
-		return fmt.Fprintf(w, "XMLName xml.Name `xml:\"%s\" json:\"%s,omitempty\"`\n",
-			node.Name.Local, strings.ToLower(node.Name.Local))
-	}
-	return fmt.Fprintf(w, "XMLName xml.Name `xml:\"%s\"`\n", node.Name.Local)
+	tag := sw.structTag(sw.xmlTagName(node.Name), FieldXMLName, node.Name.Local, "XMLName", false, false)
+	return fmt.Fprintf(w, "XMLName xml.Name %s\n", tag)
 }
 
 // writeChardataField writes a chardata field. Might add a comment as well.
@@ -148,7 +351,7 @@ func (sw *StructWriter) writeChardataField(w io.Writer, node *Node) (int, error)
 			}
 		}
 		for _, child := range node.Children {
-			if name == sw.NameFunc(child.Name.Local) {
+			if name == sw.goName(child.Name) {
 				return false
 			}
 		}
@@ -171,12 +374,9 @@ func (sw *StructWriter) writeChardataField(w io.Writer, node *Node) (int, error)
 		return 0, fmt.Errorf("name clash, text field")
 	}
 
-	var s string
-	if sw.WithJSONTags {
-		s = fmt.Sprintf("%s string `xml:\",chardata\" json:\"%s,omitempty\"`", textFieldName, strings.ToLower(textFieldName))
-	} else {
-		s = fmt.Sprintf("%s string `xml:\",chardata\"`", textFieldName)
-	}
+	t := sw.typeForExamples(textFieldName, node.Examples)
+	tag := sw.structTag(",chardata", FieldChardata, textFieldName, textFieldName, false, t.pointer)
+	s := fmt.Sprintf("%s %s %s", textFieldName, t, tag)
 
 	if sw.UniqueExamples {
 		node.Examples = uniqueStrings(node.Examples)
@@ -186,23 +386,28 @@ func (sw *StructWriter) writeChardataField(w io.Writer, node *Node) (int, error)
 		examples := strings.Replace(strings.Join(node.Examples, ", "), "\n", " ", -1)
 		s = fmt.Sprintf("%s // %s", s, truncateString(examples, sw.ExampleMaxChars, "..."))
 	}
+	if reason, ok := sw.LossyFields[node]; ok {
+		s = fmt.Sprintf("%s // zek: lossy (%s)", s, reason)
+	}
 	return fmt.Fprintf(w, "%s\n", s)
 }
 
-// writeAttrField writes an attribute field.
-func (sw *StructWriter) writeAttrField(w io.Writer, name, typeName string, attr xml.Attr) (int, error) {
-	if sw.WithJSONTags {
-		return fmt.Fprintf(w, "%s %s `xml:\"%s,attr\" json:\"%s,omitempty\"`\n", name, typeName, attr.Name.Local, strings.ToLower(attr.Name.Local))
-	}
-	return fmt.Fprintf(w, "%s %s `xml:\"%s,attr\"`\n", name, typeName, attr.Name.Local)
+// writeAttrField writes an attribute field, inferring its type from the
+// attribute's observed values. name is passed as the TagEmitter localName,
+// not attr.Name.Local, so that an attribute renamed by AttributePrefixes to
+// avoid a Go name clash (e.g. with the chardata field) also gets a distinct
+// tag, instead of silently reintroducing the same duplicate-key problem
+// under a different name.
+func (sw *StructWriter) writeAttrField(w io.Writer, name string, attr Attr) (int, error) {
+	t := sw.typeForExamples(name, attr.Examples)
+	tag := sw.structTag(sw.xmlTagName(attr.Name)+",attr", FieldAttr, name, name, false, t.pointer)
+	return fmt.Fprintf(w, "%s %s %s\n", name, t, tag)
 }
 
 // writeStructTag writes xml tag at the end of struct declaration.
 func (sw *StructWriter) writeStructTag(w io.Writer, node *Node) (int, error) {
-	if sw.WithJSONTags {
-		return fmt.Fprintf(w, "`xml:\"%s\" json:\"%s,omitempty\"`", node.Name.Local, strings.ToLower(node.Name.Local))
-	}
-	return fmt.Fprintf(w, "`xml:\"%s\"`", node.Name.Local)
+	tag := sw.structTag(sw.xmlTagName(node.Name), FieldChild, node.Name.Local, sw.goName(node.Name), node.IsMultivalued(), false)
+	return io.WriteString(w, tag)
 }
 
 // writeNode writes out the node as a struct. Output is not formatted.
@@ -211,11 +416,11 @@ func (sw *StructWriter) writeNode(node *Node, top bool) (err error) {
 	if top {
 		if sw.Banner != "" {
 			io.WriteString(sew, fmt.Sprintf("// %s was %s\n",
-				sw.NameFunc(node.Name.Local), sw.Banner))
+				sw.goName(node.Name), sw.Banner))
 		}
 		io.WriteString(sew, "type ")
 	}
-	io.WriteString(sew, sw.NameFunc(node.Name.Local))
+	io.WriteString(sew, sw.goName(node.Name))
 	io.WriteString(sew, " ")
 	if node.IsMultivalued() && !top {
 		io.WriteString(sew, "[]")
@@ -226,11 +431,15 @@ func (sw *StructWriter) writeNode(node *Node, top bool) (err error) {
 	}
 
 	if sw.Compact && len(node.Children) == 0 && len(node.Attr) == 0 {
-		s := fmt.Sprintf("string `xml:\"%s\"`", node.Name.Local)
+		t := sw.typeForExamples(sw.goName(node.Name), node.Examples)
+		s := fmt.Sprintf("%s `xml:\"%s\"`", t, sw.xmlTagName(node.Name))
 		if sw.WithComments && len(node.Examples) > 0 {
 			examples := strings.Replace(strings.Join(node.Examples, ", "), "\n", " ", -1)
 			s = fmt.Sprintf("%s // %s", s, truncateString(examples, sw.ExampleMaxChars, "..."))
 		}
+		if reason, ok := sw.LossyFields[node]; ok {
+			s = fmt.Sprintf("%s // zek: lossy (%s)", s, reason)
+		}
 		fmt.Fprintf(sew, "%s\n", s)
 		return err
 	}
@@ -247,7 +456,7 @@ func (sw *StructWriter) writeNode(node *Node, top bool) (err error) {
 			return false
 		}
 		for _, child := range node.Children {
-			if name == sw.NameFunc(child.Name.Local) {
+			if name == sw.goName(child.Name) {
 				return false
 			}
 		}
@@ -257,7 +466,7 @@ func (sw *StructWriter) writeNode(node *Node, top bool) (err error) {
 	// Write attributes. XXX: Better handling of duplicate attributes.
 	written := make(map[string]bool)
 	for _, attr := range node.Attr {
-		name := sw.NameFunc(attr.Name.Local)
+		name := sw.goName(attr.Name)
 		for _, prefix := range sw.AttributePrefixes {
 			if isValidName(name) {
 				break
@@ -267,16 +476,17 @@ func (sw *StructWriter) writeNode(node *Node, top bool) (err error) {
 		if !isValidName(name) {
 			return fmt.Errorf("name clash: %s", attr.Name.Local)
 		}
-		if _, ok := written[attr.Name.Local]; ok {
+		seenKey := attr.Name.Space + "|" + attr.Name.Local
+		if _, ok := written[seenKey]; ok {
 			if sw.Strict {
-				log.Fatalf("[not implemented] duplicate local attribute name: %s", attr)
+				log.Fatalf("[not implemented] duplicate local attribute name: %s", attr.Name.Local)
 			} else {
-				log.Printf("warning: duplicate local attribute name: %s", attr)
+				log.Printf("warning: duplicate local attribute name: %s", attr.Name.Local)
 			}
 			continue
 		}
-		sw.writeAttrField(sew, name, "string", attr)
-		written[attr.Name.Local] = true
+		sw.writeAttrField(sew, name, attr)
+		written[seenKey] = true
 	}
 
 	// Write children.
@@ -0,0 +1,152 @@
+package zek
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldKind identifies which part of a generated struct a TagEmitter is
+// asked to produce a tag for.
+type FieldKind int
+
+const (
+	// FieldXMLName is the struct's XMLName xml.Name field.
+	FieldXMLName FieldKind = iota
+	// FieldChardata is the field holding an element's text content.
+	FieldChardata
+	// FieldAttr is a field generated from an XML attribute.
+	FieldAttr
+	// FieldChild is a field generated from a child element.
+	FieldChild
+)
+
+// TagEmitter produces one struct tag key's value for a generated field.
+// StructWriter calls Tag for every configured emitter and folds a
+// non-empty result into the field's struct tag.
+type TagEmitter interface {
+	// Key is the tag key this emitter writes, e.g. "json" for `json:"..."`.
+	Key() string
+	// Tag returns the tag value for a field, or "" to omit the key
+	// entirely for this field. localName is the observed XML name for
+	// FieldXMLName and FieldChild; for FieldChardata and FieldAttr it is
+	// the generated Go field name (e.g. "Text", or "AttrText" for an
+	// attribute renamed to avoid a clash). goName is the generated Go
+	// field or type name, multivalued reports
+	// whether the field can occur more than once, and optional whether
+	// it may be absent (and was therefore generated as a pointer).
+	Tag(kind FieldKind, localName, goName string, multivalued, optional bool) string
+}
+
+// JSONTagEmitter emits encoding/json struct tags, lower-casing the XML
+// local name and always adding omitempty.
+type JSONTagEmitter struct{}
+
+// Key implements TagEmitter.
+func (JSONTagEmitter) Key() string { return "json" }
+
+// Tag implements TagEmitter.
+func (JSONTagEmitter) Tag(kind FieldKind, localName, goName string, multivalued, optional bool) string {
+	return strings.ToLower(localName) + ",omitempty"
+}
+
+// YAMLTagEmitter emits gopkg.in/yaml.v3 struct tags, lower-casing the XML
+// local name and always adding omitempty.
+type YAMLTagEmitter struct{}
+
+// Key implements TagEmitter.
+func (YAMLTagEmitter) Key() string { return "yaml" }
+
+// Tag implements TagEmitter.
+func (YAMLTagEmitter) Tag(kind FieldKind, localName, goName string, multivalued, optional bool) string {
+	return strings.ToLower(localName) + ",omitempty"
+}
+
+// TOMLTagEmitter emits BurntSushi/toml struct tags. The toml package has no
+// omitempty support, so only the lower-cased name is emitted.
+type TOMLTagEmitter struct{}
+
+// Key implements TagEmitter.
+func (TOMLTagEmitter) Key() string { return "toml" }
+
+// Tag implements TagEmitter.
+func (TOMLTagEmitter) Tag(kind FieldKind, localName, goName string, multivalued, optional bool) string {
+	return strings.ToLower(localName)
+}
+
+// BSONTagEmitter emits mongo-driver struct tags, lower-casing the XML local
+// name and adding omitempty.
+type BSONTagEmitter struct{}
+
+// Key implements TagEmitter.
+func (BSONTagEmitter) Key() string { return "bson" }
+
+// Tag implements TagEmitter.
+func (BSONTagEmitter) Tag(kind FieldKind, localName, goName string, multivalued, optional bool) string {
+	return strings.ToLower(localName) + ",omitempty"
+}
+
+// DBTagEmitter emits sqlx/sqlboiler style struct tags: snake_case column
+// names. XMLName and child-element fields have no corresponding column, so
+// they are left untagged.
+type DBTagEmitter struct{}
+
+// Key implements TagEmitter.
+func (DBTagEmitter) Key() string { return "db" }
+
+// Tag implements TagEmitter.
+func (DBTagEmitter) Tag(kind FieldKind, localName, goName string, multivalued, optional bool) string {
+	if kind == FieldXMLName || kind == FieldChild {
+		return ""
+	}
+	return toSnakeCase(localName)
+}
+
+// toSnakeCase converts a CamelCase or camelCase identifier to snake_case, as
+// expected by db tag conventions. A run of consecutive capitals (an
+// acronym like ISBN) is kept together; an underscore is only inserted at a
+// lower-to-upper boundary, or where an acronym run hands off to a new
+// Titlecase word (e.g. "XMLName" -> "xml_name", "ISBN" -> "isbn").
+func toSnakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			prev := runes[i-1]
+			prevLower := prev >= 'a' && prev <= 'z'
+			nextLower := i+1 < len(runes) && runes[i+1] >= 'a' && runes[i+1] <= 'z'
+			if prevLower || (prev >= 'A' && prev <= 'Z' && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(r)
+	}
+	return strings.ToLower(b.String())
+}
+
+// ParseTagEmitters parses a comma-separated list of emitter names, as used
+// by the zek CLI's -tags flag (e.g. "json,yaml,db"), into TagEmitters, in
+// the given order.
+func ParseTagEmitters(spec string) ([]TagEmitter, error) {
+	var out []TagEmitter
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		switch name {
+		case "json":
+			out = append(out, JSONTagEmitter{})
+		case "yaml":
+			out = append(out, YAMLTagEmitter{})
+		case "toml":
+			out = append(out, TOMLTagEmitter{})
+		case "bson":
+			out = append(out, BSONTagEmitter{})
+		case "db":
+			out = append(out, DBTagEmitter{})
+		default:
+			return nil, fmt.Errorf("unknown tag emitter: %s", name)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,72 @@
+package zek
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestToSnakeCase(t *testing.T) {
+	cases := map[string]string{
+		"ISBN":      "isbn",
+		"BookTitle": "book_title",
+		"id":        "id",
+	}
+	for in, want := range cases {
+		if got := toSnakeCase(in); got != want {
+			t.Errorf("toSnakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseTagEmitters(t *testing.T) {
+	emitters, err := ParseTagEmitters("json,yaml,db")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(emitters) != 3 {
+		t.Fatalf("got %d emitters, want 3", len(emitters))
+	}
+	if _, err := ParseTagEmitters("bogus"); err == nil {
+		t.Fatalf("expected error for unknown emitter")
+	}
+}
+
+func TestStructWriterTagEmittersShim(t *testing.T) {
+	sw := &StructWriter{WithJSONTags: true, TagEmitters: []TagEmitter{YAMLTagEmitter{}}}
+	tag := sw.structTag("foo", FieldChardata, "foo", "Text", false, false)
+	if tag != "`xml:\"foo\" yaml:\"foo,omitempty\" json:\"foo,omitempty\"`" {
+		t.Fatalf("unexpected tag: %s", tag)
+	}
+}
+
+// TestStructWriterAttrJSONTagAvoidsChardataClash exercises an attribute
+// literally named "text" on an element whose default chardata field name
+// is "Text": since both would otherwise produce the JSON key "text,
+// AttributePrefixes renames the Go field to AttrText, and its json tag
+// must follow suit rather than keying off the attribute's original local
+// name, or the two fields would silently share a json key again.
+func TestStructWriterAttrJSONTagAvoidsChardataClash(t *testing.T) {
+	node := &Node{
+		Name: xml.Name{Local: "para"},
+		Attr: []Attr{
+			{Name: xml.Name{Local: "text"}, Examples: []string{"x"}, Count: 1},
+		},
+		Examples: []string{"hello"},
+	}
+	var buf bytes.Buffer
+	sw := NewStructWriter(&buf)
+	sw.Banner = ""
+	sw.TagEmitters = []TagEmitter{JSONTagEmitter{}}
+	if err := sw.WriteNode(node); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	if strings.Count(out, `json:"text,omitempty"`) != 1 {
+		t.Fatalf("expected json key \"text\" to be used exactly once, got:\n%s", out)
+	}
+	if !strings.Contains(out, `json:"attrtext,omitempty"`) {
+		t.Fatalf("expected the renamed attribute field to get a distinct json key, got:\n%s", out)
+	}
+}
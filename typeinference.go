@@ -0,0 +1,126 @@
+package zek
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeLayouts are the layouts tried, in order, when StructWriter.TypeInference
+// is enabled and a field's examples look like timestamps.
+var DefaultTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02",
+	"2006-01-02T15:04:05",
+}
+
+// goType is the result of inferring a Go type from a set of observed
+// example values: the base type name, whether the field should be a
+// pointer (because at least one example was missing), and - for time.Time
+// fields only - the layout chosen to parse it.
+type goType struct {
+	name    string // "bool", "int64", "float64", "time.Time" or "string"
+	pointer bool
+	layout  string
+}
+
+// String renders the type as it would appear in a struct field declaration.
+func (t goType) String() string {
+	if t.pointer {
+		return "*" + t.name
+	}
+	return t.name
+}
+
+// inferType picks the narrowest Go type that fits every non-empty string in
+// examples, trying bool, then int64, then float64, then time.Time (against
+// each of layouts, in order), and falling back to string. A single empty
+// example widens the field to a pointer, so elements or attributes that are
+// sometimes absent do not unmarshal into a misleading zero value. With no
+// non-empty examples at all, inferType falls back to string.
+//
+// "0"/"1" alone are treated as int64, not bool, since that is by far the
+// more common meaning (counts, flags stored as small integers, etc); they
+// only count towards bool when at least one example is spelled out as
+// "true"/"false", so a field only ever becomes bool given actual evidence
+// of boolean intent.
+func inferType(examples []string, layouts []string) goType {
+	var (
+		sawEmpty, sawValue, sawTrueFalse bool
+		isBool, isInt, isFloat           = true, true, true
+	)
+	layoutOK := make([]bool, len(layouts))
+	for i := range layoutOK {
+		layoutOK[i] = true
+	}
+	for _, s := range examples {
+		if s == "" {
+			sawEmpty = true
+			continue
+		}
+		sawValue = true
+		if isTrueFalseLiteral(s) {
+			sawTrueFalse = true
+		}
+		if isBool && !isBoolLiteral(s) {
+			isBool = false
+		}
+		if isInt {
+			if _, err := strconv.ParseInt(s, 10, 64); err != nil {
+				isInt = false
+			}
+		}
+		if isFloat {
+			if _, err := strconv.ParseFloat(s, 64); err != nil {
+				isFloat = false
+			}
+		}
+		for i, layout := range layouts {
+			if !layoutOK[i] {
+				continue
+			}
+			if _, err := time.Parse(layout, s); err != nil {
+				layoutOK[i] = false
+			}
+		}
+	}
+	if !sawValue {
+		return goType{name: "string", pointer: sawEmpty}
+	}
+	switch {
+	case isBool && sawTrueFalse:
+		return goType{name: "bool", pointer: sawEmpty}
+	case isInt:
+		return goType{name: "int64", pointer: sawEmpty}
+	case isFloat:
+		return goType{name: "float64", pointer: sawEmpty}
+	}
+	for i, ok := range layoutOK {
+		if ok {
+			return goType{name: "time.Time", pointer: sawEmpty, layout: layouts[i]}
+		}
+	}
+	return goType{name: "string", pointer: sawEmpty}
+}
+
+// isTrueFalseLiteral reports whether s is spelled out as "true" or "false",
+// as opposed to the "0"/"1" shorthand isBoolLiteral also accepts.
+func isTrueFalseLiteral(s string) bool {
+	switch strings.ToLower(s) {
+	case "true", "false":
+		return true
+	default:
+		return false
+	}
+}
+
+// isBoolLiteral reports whether s is one of the literal spellings of a
+// boolean value that zek's type inference accepts.
+func isBoolLiteral(s string) bool {
+	switch strings.ToLower(s) {
+	case "true", "false", "0", "1":
+		return true
+	default:
+		return false
+	}
+}
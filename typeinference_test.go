@@ -0,0 +1,105 @@
+package zek
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestInferType(t *testing.T) {
+	cases := []struct {
+		about    string
+		examples []string
+		want     goType
+	}{
+		{"empty", nil, goType{name: "string"}},
+		{"all empty", []string{"", ""}, goType{name: "string", pointer: true}},
+		{"bool", []string{"true", "false"}, goType{name: "bool"}},
+		{"bool mixed with 0/1", []string{"true", "0", "1"}, goType{name: "bool"}},
+		{"0/1 alone is int, not bool", []string{"0", "1"}, goType{name: "int64"}},
+		{"int", []string{"1", "2", "3"}, goType{name: "int64"}},
+		{"int widened by float", []string{"1", "2", "3.5"}, goType{name: "float64"}},
+		{"int widened by string", []string{"1", "2", "abc"}, goType{name: "string"}},
+		{"float widened by string", []string{"1.5", "2.5", "abc"}, goType{name: "string"}},
+		{"pointer int on missing example", []string{"1", ""}, goType{name: "int64", pointer: true}},
+		{"rfc3339", []string{"2020-01-02T15:04:05Z"}, goType{name: "time.Time", layout: DefaultTimeLayouts[0]}},
+		{"date only", []string{"2020-01-02", "2020-06-01"}, goType{name: "time.Time", layout: "2006-01-02"}},
+	}
+	for _, c := range cases {
+		t.Run(c.about, func(t *testing.T) {
+			got := inferType(c.examples, DefaultTimeLayouts)
+			if got.name != c.want.name || got.pointer != c.want.pointer || got.layout != c.want.layout {
+				t.Fatalf("inferType(%v) = %+v, want %+v", c.examples, got, c.want)
+			}
+		})
+	}
+}
+
+func TestStructWriterTypeInferencePromotion(t *testing.T) {
+	sw := &StructWriter{TypeInference: true, TimeLayouts: DefaultTimeLayouts}
+	typ := sw.typeForExamples("Count", []string{"1", "2"})
+	if typ.String() != "int64" {
+		t.Fatalf("got %s, want int64", typ)
+	}
+	typ = sw.typeForExamples("Count", []string{"1", "2.5"})
+	if typ.String() != "float64" {
+		t.Fatalf("got %s, want float64", typ)
+	}
+	typ = sw.typeForExamples("Count", []string{"1", "2.5", "x"})
+	if typ.String() != "string" {
+		t.Fatalf("got %s, want string", typ)
+	}
+}
+
+func TestRegisterTimeHelperAvoidsNameClash(t *testing.T) {
+	sw := &StructWriter{}
+	first := sw.registerTimeHelper("Updated", "2006-01-02")
+	second := sw.registerTimeHelper("Updated", "2006-01-02")
+	if first == second {
+		t.Fatalf("expected distinct helper names, got %q twice", first)
+	}
+	if second != "UpdatedTime2" {
+		t.Fatalf("got %q, want UpdatedTime2", second)
+	}
+}
+
+// TestRegisterTimeHelperAvoidsStructNameClash exercises the case where a
+// time.Time helper's minted name ("Text" + "Time") equals the Go name of
+// the element itself: <textTime x="a">2020-01-02T15:04:05Z</textTime>
+// would otherwise emit "type TextTime struct{...}" and a second "type
+// TextTime struct{ time.Time }" helper, which does not compile.
+func TestRegisterTimeHelperAvoidsStructNameClash(t *testing.T) {
+	node := &Node{
+		Name: xml.Name{Local: "textTime"},
+		Attr: []Attr{
+			{Name: xml.Name{Local: "x"}, Examples: []string{"a"}, Count: 1},
+		},
+		Examples: []string{"2020-01-02T15:04:05Z"},
+		Count:    1,
+	}
+
+	var buf bytes.Buffer
+	sw := NewStructWriter(&buf)
+	sw.Banner = ""
+	sw.TypeInference = true
+	if err := sw.WriteNode(node); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	source := buf.String()
+	if strings.Count(source, "type TextTime struct") != 1 {
+		t.Fatalf("expected exactly one TextTime struct declaration, got:\n%s", source)
+	}
+	if !strings.Contains(source, "TextTime2") {
+		t.Fatalf("expected the helper to be renamed to TextTime2, got:\n%s", source)
+	}
+
+	sample := []byte(`<textTime x="a">2020-01-02T15:04:05Z</textTime>`)
+	report, err := RoundTrip(sample, source, "TextTime", nil)
+	if err != nil {
+		t.Fatalf("generated source does not compile: %v", err)
+	}
+	if !report.OK {
+		t.Fatalf("expected a lossless round trip, got lossy fields: %+v", report.Lossy)
+	}
+}